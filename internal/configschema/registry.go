@@ -0,0 +1,258 @@
+// Package configschema is the single source of truth for every DiceDB
+// startup setting. Each setting is described once, as an Entry, and that
+// single description drives CLI flag registration, YAML/TOML config-file
+// parsing, and DICE_<UPPER_SNAKE> environment variable overrides.
+//
+// Precedence is flag > env > file > built-in default. Entries remember
+// which layer last set them so printConfigTable in main.go can report it.
+package configschema
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Source identifies which configuration layer last set an Entry's value.
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+// envPrefix is prepended to the upper-snake-case flag name to derive the
+// environment variable DiceDB reads, e.g. flag "wal-engine" -> DICE_WAL_ENGINE.
+const envPrefix = "DICE_"
+
+// Entry is one named, typed setting shared across the flag, file, and env
+// layers. Exactly one of the *Ptr fields is set, matching Kind.
+type Entry struct {
+	Name   string // flag name, e.g. "wal-engine"
+	Usage  string
+	Kind   Kind
+	Source Source
+
+	StrPtr  *string
+	IntPtr  *int
+	BoolPtr *bool
+}
+
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindInt
+	KindBool
+)
+
+// EnvName returns the DICE_<UPPER_SNAKE> environment variable for this entry.
+func (e *Entry) EnvName() string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(e.Name, "-", "_"))
+}
+
+// Value returns the entry's current value as printed in the config table.
+func (e *Entry) Value() interface{} {
+	switch e.Kind {
+	case KindString:
+		return *e.StrPtr
+	case KindInt:
+		return *e.IntPtr
+	case KindBool:
+		return *e.BoolPtr
+	default:
+		return nil
+	}
+}
+
+// Registry collects every Entry in the order they were registered, so
+// main.go's init() can describe each setting exactly once.
+type Registry struct {
+	entries []*Entry
+}
+
+func New() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) StringVar(p *string, name, def, usage string) {
+	*p = def
+	r.entries = append(r.entries, &Entry{Name: name, Usage: usage, Kind: KindString, Source: SourceDefault, StrPtr: p})
+}
+
+func (r *Registry) IntVar(p *int, name string, def int, usage string) {
+	*p = def
+	r.entries = append(r.entries, &Entry{Name: name, Usage: usage, Kind: KindInt, Source: SourceDefault, IntPtr: p})
+}
+
+func (r *Registry) BoolVar(p *bool, name string, def bool, usage string) {
+	*p = def
+	r.entries = append(r.entries, &Entry{Name: name, Usage: usage, Kind: KindBool, Source: SourceDefault, BoolPtr: p})
+}
+
+// RegisterFlags binds every entry onto fs, using its current value (after
+// LoadFile/LoadEnv have already been applied) as the flag's default, so an
+// unset flag preserves whatever the file/env layers resolved. After
+// fs.Parse, call MarkFlagSet with each flag's name from an fs.Visit
+// callback to record which entries the command line actually overrode.
+func (r *Registry) RegisterFlags(fs *flag.FlagSet) {
+	for _, e := range r.entries {
+		switch e.Kind {
+		case KindString:
+			fs.StringVar(e.StrPtr, e.Name, *e.StrPtr, e.Usage)
+		case KindInt:
+			fs.IntVar(e.IntPtr, e.Name, *e.IntPtr, e.Usage)
+		case KindBool:
+			fs.BoolVar(e.BoolPtr, e.Name, *e.BoolPtr, e.Usage)
+		}
+	}
+}
+
+// Entries returns every registered setting, in registration order.
+func (r *Registry) Entries() []*Entry {
+	return r.entries
+}
+
+func (r *Registry) byName(name string) *Entry {
+	for _, e := range r.entries {
+		if e.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// LoadFile overlays values from a YAML or TOML config file, keyed by flag
+// name (dashes or underscores, either works). Missing files are not an
+// error -- a config file is optional at every layer. The format is chosen
+// from the file extension, defaulting to YAML.
+func (r *Registry) LoadFile(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("could not read config file %q: %w", path, err)
+	}
+
+	raw := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml":
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("could not parse TOML config file %q: %w", path, err)
+		}
+	default:
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("could not parse YAML config file %q: %w", path, err)
+		}
+	}
+
+	for key, v := range raw {
+		name := strings.ReplaceAll(key, "_", "-")
+		e := r.byName(name)
+		if e == nil {
+			continue
+		}
+		if err := e.setFrom(fmt.Sprintf("%v", v), SourceFile); err != nil {
+			return fmt.Errorf("config file %q: invalid value for %q: %w", path, key, err)
+		}
+	}
+
+	return nil
+}
+
+// LoadEnv overlays values from DICE_<UPPER_SNAKE> environment variables,
+// overriding anything set by LoadFile but still subordinate to flags,
+// which are applied afterwards by flag.Parse on top of these values.
+func (r *Registry) LoadEnv() error {
+	for _, e := range r.entries {
+		v, ok := os.LookupEnv(e.EnvName())
+		if !ok {
+			continue
+		}
+		if err := e.setFrom(v, SourceEnv); err != nil {
+			return fmt.Errorf("environment variable %s: %w", e.EnvName(), err)
+		}
+	}
+	return nil
+}
+
+// MarkFlagSet marks a single flag name as sourced from the CLI. Call it
+// with f.Name from a flag.Visit callback after flag.Parse.
+func (r *Registry) MarkFlagSet(name string) {
+	if e := r.byName(name); e != nil {
+		e.Source = SourceFlag
+	}
+}
+
+// SetSource overrides name's Source directly, bypassing the normal
+// LoadFile/LoadEnv/flag layers. It exists for settings like "c" that must
+// be resolved by a caller-specific mechanism (ResolveConfigPath) before the
+// registry itself can be consulted, so their reported Source doesn't
+// depend on incidentally being re-derived by a later LoadEnv/flag.Visit
+// pass over the same variable.
+func (r *Registry) SetSource(name string, source Source) {
+	if e := r.byName(name); e != nil {
+		e.Source = source
+	}
+}
+
+// ResolveConfigPath finds the config file path to load before any other
+// entry can be resolved, and which layer supplied it: it pre-scans args
+// for -flagName (flags aren't parsed yet at this point), then
+// DICE_<UPPER_SNAKE> of flagName, then def.
+func ResolveConfigPath(args []string, flagName, def string) (string, Source) {
+	prefix := "-" + flagName
+	for i, a := range args {
+		switch {
+		case a == prefix || a == "-"+prefix:
+			if i+1 < len(args) {
+				return args[i+1], SourceFlag
+			}
+		case strings.HasPrefix(a, prefix+"="):
+			return strings.TrimPrefix(a, prefix+"="), SourceFlag
+		case strings.HasPrefix(a, "-"+prefix+"="):
+			return strings.TrimPrefix(a, "-"+prefix+"="), SourceFlag
+		}
+	}
+
+	envName := envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+	if v, ok := os.LookupEnv(envName); ok {
+		return v, SourceEnv
+	}
+
+	return def, SourceDefault
+}
+
+func (e *Entry) setFrom(raw string, source Source) error {
+	switch e.Kind {
+	case KindString:
+		*e.StrPtr = raw
+	case KindInt:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("expected int, got %q: %w", raw, err)
+		}
+		*e.IntPtr = n
+	case KindBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("expected bool, got %q: %w", raw, err)
+		}
+		*e.BoolPtr = b
+	}
+	e.Source = source
+	return nil
+}
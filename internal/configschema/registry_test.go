@@ -0,0 +1,127 @@
+package configschema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegistryDefault(t *testing.T) {
+	r := New()
+	var host string
+	r.StringVar(&host, "host", "0.0.0.0", "host")
+
+	if host != "0.0.0.0" {
+		t.Fatalf("host = %q, want default", host)
+	}
+	if got := r.byName("host").Source; got != SourceDefault {
+		t.Fatalf("source = %q, want %q", got, SourceDefault)
+	}
+}
+
+func TestRegistryLoadFileOverridesDefault(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("host: 127.0.0.1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := New()
+	var host string
+	r.StringVar(&host, "host", "0.0.0.0", "host")
+
+	if err := r.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if host != "127.0.0.1" {
+		t.Fatalf("host = %q, want file value", host)
+	}
+	if got := r.byName("host").Source; got != SourceFile {
+		t.Fatalf("source = %q, want %q", got, SourceFile)
+	}
+}
+
+func TestRegistryLoadEnvOverridesFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("port: 1111\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := New()
+	var port int
+	r.IntVar(&port, "port", 7379, "port")
+
+	t.Setenv("DICE_PORT", "2222")
+
+	if err := r.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.LoadEnv(); err != nil {
+		t.Fatal(err)
+	}
+
+	if port != 2222 {
+		t.Fatalf("port = %d, want env value", port)
+	}
+	if got := r.byName("port").Source; got != SourceEnv {
+		t.Fatalf("source = %q, want %q", got, SourceEnv)
+	}
+}
+
+func TestMarkFlagSetOverridesEnv(t *testing.T) {
+	r := New()
+	var port int
+	r.IntVar(&port, "port", 7379, "port")
+
+	t.Setenv("DICE_PORT", "2222")
+	if err := r.LoadEnv(); err != nil {
+		t.Fatal(err)
+	}
+
+	r.MarkFlagSet("port")
+
+	if got := r.byName("port").Source; got != SourceFlag {
+		t.Fatalf("source = %q, want %q", got, SourceFlag)
+	}
+}
+
+func TestResolveConfigPathPrecedence(t *testing.T) {
+	cases := []struct {
+		name    string
+		args    []string
+		env     string
+		def     string
+		want    string
+		wantSrc Source
+	}{
+		{name: "default", def: "default.yaml", want: "default.yaml", wantSrc: SourceDefault},
+		{name: "env", env: "env.yaml", def: "default.yaml", want: "env.yaml", wantSrc: SourceEnv},
+		{name: "flag beats env", args: []string{"-c", "flag.yaml"}, env: "env.yaml", def: "default.yaml", want: "flag.yaml", wantSrc: SourceFlag},
+		{name: "flag equals form", args: []string{"-c=flag.yaml"}, def: "default.yaml", want: "flag.yaml", wantSrc: SourceFlag},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.env != "" {
+				t.Setenv("DICE_C", tc.env)
+			}
+			got, src := ResolveConfigPath(tc.args, "c", tc.def)
+			if got != tc.want || src != tc.wantSrc {
+				t.Fatalf("ResolveConfigPath() = (%q, %q), want (%q, %q)", got, src, tc.want, tc.wantSrc)
+			}
+		})
+	}
+}
+
+func TestSetSource(t *testing.T) {
+	r := New()
+	var c string
+	r.StringVar(&c, "c", "default.yaml", "config file")
+	r.SetSource("c", SourceEnv)
+
+	if got := r.byName("c").Source; got != SourceEnv {
+		t.Fatalf("source = %q, want %q", got, SourceEnv)
+	}
+}
@@ -0,0 +1,78 @@
+// Package lifecycle tracks DiceDB's readiness through a graceful,
+// two-phase shutdown: draining (stop taking new work, finish what's
+// in-flight) followed by cancellation (tear everything down). It backs
+// the /healthz, /livez, and /readyz admin endpoints.
+package lifecycle
+
+import (
+	"net"
+	"net/http"
+	"sync/atomic"
+)
+
+// Gate tracks whether the process has started draining. Server
+// implementations should consult Draining before accepting a new
+// connection, and keep serving connections accepted before it flipped.
+type Gate struct {
+	draining atomic.Bool
+}
+
+// NewGate returns a Gate that is not draining.
+func NewGate() *Gate {
+	return &Gate{}
+}
+
+// Draining reports whether the process has begun a graceful shutdown.
+// abstractserver implementations should stop accepting new RESP/HTTP/
+// WebSocket connections once this is true, while letting connections
+// already in flight finish. RejectNewConnections is the http.Server
+// wiring for that; non-HTTP listeners need the equivalent check in their
+// own Accept loop.
+func (g *Gate) Draining() bool {
+	return g.draining.Load()
+}
+
+// RejectNewConnections is an http.Server.ConnState hook: assign it to
+// Server.ConnState and it closes every connection that arrives in
+// StateNew once draining has begun, without touching connections already
+// in flight. This is how the admin HTTP listener in main.go honors
+// draining today; RESP/HTTP/WebSocket abstractserver implementations
+// should wire the same hook (or an equivalent check in their raw
+// net.Listener.Accept loop) once they're part of this change.
+func (g *Gate) RejectNewConnections(conn net.Conn, state http.ConnState) {
+	if state == http.StateNew && g.Draining() {
+		_ = conn.Close()
+	}
+}
+
+// BeginDraining flips the gate. It's idempotent: a second signal calling
+// this again is a no-op, the caller decides separately whether to force
+// an immediate abort on a repeated signal.
+func (g *Gate) BeginDraining() {
+	g.draining.Store(true)
+}
+
+// Mount registers /healthz, /livez, and /readyz on mux.
+//
+//   - /livez always returns 200 as long as the process is up, for
+//     liveness probes that should only restart a truly wedged process.
+//   - /healthz mirrors /livez today; it's the generic health check most
+//     tooling defaults to.
+//   - /readyz returns 200 until draining starts, then 503, so a load
+//     balancer stops routing new connections here without killing
+//     connections already being served.
+func (g *Gate) Mount(mux *http.ServeMux) {
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		if g.Draining() {
+			http.Error(w, "draining", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
@@ -0,0 +1,49 @@
+package otel
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+func serverAttr(server string) attribute.KeyValue {
+	return attribute.String("server", server)
+}
+
+// Metrics is the fixed set of instruments this change actually populates:
+// one gauge per server type tracking whether its Run goroutine is alive.
+// CommandsExecuted, WAL-append latency, and watch fan-out latency are the
+// request-lifecycle instruments the full integration calls for, but
+// nothing in this tree's reach -- main.go only sees a server's Run
+// goroutine start and exit, not individual commands -- can record them
+// without a hook inside internal/shard/internal/store, so they aren't
+// declared here rather than shipped unused.
+type Metrics struct {
+	serverUp metric.Int64UpDownCounter
+}
+
+func newMetrics(meter metric.Meter) (*Metrics, error) {
+	serverUp, err := meter.Int64UpDownCounter(
+		"dicedb.server.up",
+		metric.WithDescription("1 while the given server type's Run goroutine is alive, 0 once it exits; "+
+			"not a per-client-connection count"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not create dicedb.server.up: %w", err)
+	}
+
+	return &Metrics{serverUp: serverUp}, nil
+}
+
+// ServerStarted records that server's Run goroutine has started. server is
+// a label, e.g. "resp", "http", "websocket".
+func (m *Metrics) ServerStarted(ctx context.Context, server string) {
+	m.serverUp.Add(ctx, 1, metric.WithAttributes(serverAttr(server)))
+}
+
+// ServerStopped records that server's Run goroutine has exited.
+func (m *Metrics) ServerStopped(ctx context.Context, server string) {
+	m.serverUp.Add(ctx, -1, metric.WithAttributes(serverAttr(server)))
+}
@@ -0,0 +1,149 @@
+// Package otel wires DiceDB into OpenTelemetry: an OTLP exporter for
+// traces and metrics, a Prometheus-compatible /metrics endpoint for
+// scrape-based deployments, and the dicedb.server.up gauge tracking each
+// server type's lifecycle. Per-request spans and metrics across the full
+// accept -> parse -> shard-dispatch -> execute -> WAL-append -> reply
+// lifecycle need a hook inside internal/shard/internal/store, which this
+// package doesn't reach into, so only the server lifecycle is covered.
+package otel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Config describes how to reach the OTLP collector and how to label this
+// instance's telemetry.
+type Config struct {
+	// Endpoint is the OTLP gRPC collector address, e.g. from
+	// --otel-endpoint or OTEL_EXPORTER_OTLP_ENDPOINT. Empty disables the
+	// OTLP exporters; the Prometheus /metrics endpoint stays available
+	// either way.
+	Endpoint   string
+	InstanceID string
+}
+
+// Providers holds the tracer and meter DiceDB uses for the lifetime of
+// the process, plus everything needed to shut them down cleanly.
+type Providers struct {
+	Tracer  trace.Tracer
+	Meter   metric.Meter
+	Metrics *Metrics
+
+	promHandler http.Handler
+	shutdownFns []func(context.Context) error
+}
+
+// ctxKey is an unexported type so values this package stores in a
+// context.Context can't collide with keys from other packages.
+type ctxKey struct{}
+
+// ContextWithProviders returns a copy of ctx carrying p, so code that
+// doesn't take a *Providers argument directly -- e.g. ShardManager.Run or
+// an abstractserver implementation -- can still reach the tracer and
+// meter via FromContext.
+func ContextWithProviders(ctx context.Context, p *Providers) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext returns the Providers stored in ctx by ContextWithProviders,
+// if any.
+func FromContext(ctx context.Context) (*Providers, bool) {
+	p, ok := ctx.Value(ctxKey{}).(*Providers)
+	return p, ok
+}
+
+// Setup builds the tracer and meter providers described by cfg and
+// registers the dicedb.server.up gauge; no per-command, WAL or watch
+// metrics are registered. Call this before constructing the ShardManager so every
+// downstream component can pull its tracer/meter from the returned
+// Providers via context.
+func Setup(ctx context.Context, cfg Config) (*Providers, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName("dicedb"),
+		semconv.ServiceInstanceID(cfg.InstanceID),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("could not build otel resource: %w", err)
+	}
+
+	p := &Providers{}
+
+	promExporter, err := prometheus.New()
+	if err != nil {
+		return nil, fmt.Errorf("could not create prometheus exporter: %w", err)
+	}
+	p.promHandler = promhttp.Handler()
+
+	meterOpts := []sdkmetric.Option{
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(promExporter),
+	}
+
+	var traceOpts []sdktrace.TracerProviderOption
+	traceOpts = append(traceOpts, sdktrace.WithResource(res))
+
+	if cfg.Endpoint != "" {
+		metricExporter, err := otlpmetricgrpc.New(ctx, otlpmetricgrpc.WithEndpoint(cfg.Endpoint), otlpmetricgrpc.WithInsecure())
+		if err != nil {
+			return nil, fmt.Errorf("could not create OTLP metric exporter: %w", err)
+		}
+		meterOpts = append(meterOpts, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter, sdkmetric.WithInterval(15*time.Second))))
+
+		traceExporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(cfg.Endpoint), otlptracegrpc.WithInsecure())
+		if err != nil {
+			return nil, fmt.Errorf("could not create OTLP trace exporter: %w", err)
+		}
+		traceOpts = append(traceOpts, sdktrace.WithBatcher(traceExporter))
+	}
+
+	meterProvider := sdkmetric.NewMeterProvider(meterOpts...)
+	tracerProvider := sdktrace.NewTracerProvider(traceOpts...)
+
+	otel.SetMeterProvider(meterProvider)
+	otel.SetTracerProvider(tracerProvider)
+
+	p.Meter = meterProvider.Meter("github.com/dicedb/dice")
+	p.Tracer = tracerProvider.Tracer("github.com/dicedb/dice")
+	p.shutdownFns = append(p.shutdownFns, meterProvider.Shutdown, tracerProvider.Shutdown)
+
+	metrics, err := newMetrics(p.Meter)
+	if err != nil {
+		return nil, fmt.Errorf("could not register metrics: %w", err)
+	}
+	p.Metrics = metrics
+
+	return p, nil
+}
+
+// Mount registers the Prometheus scrape endpoint on mux.
+func (p *Providers) Mount(mux *http.ServeMux) {
+	mux.Handle("/metrics", p.promHandler)
+}
+
+// Shutdown flushes and closes the tracer and meter providers. Give it a
+// few seconds in the shutdown-drain window so a final batch of spans and
+// metrics makes it to the collector.
+func (p *Providers) Shutdown(ctx context.Context) error {
+	var firstErr error
+	for _, fn := range p.shutdownFns {
+		if err := fn(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,186 @@
+// Package profiling provides the two ways DiceDB captures runtime profiles:
+//
+//   - file-dump mode, started with --enable-profiling, which writes
+//     cpu/mem/block/trace profiles to disk on shutdown, and
+//   - HTTP mode, mounted on the admin HTTP server (or a dedicated
+//     --profiling-port listener) so operators can pull profiles from a
+//     live instance via the standard net/http/pprof handlers, without a
+//     restart.
+package profiling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/trace"
+	"strconv"
+
+	pprofrt "runtime/pprof"
+)
+
+// StopFunc flushes and closes whatever profiling artifacts were opened and
+// returns the first error encountered while doing so, if any.
+type StopFunc func() error
+
+// StartFileDump starts CPU, memory, block, and execution-trace profiling and
+// writes the results under dir when the returned StopFunc is called. It is
+// the file-dump counterpart of Mount, used when the operator wants a single
+// capture for the lifetime of the process rather than an on-demand HTTP pull.
+func StartFileDump(dir string) (StopFunc, error) {
+	cpuFile, err := createProfile(dir, "cpu.prof")
+	if err != nil {
+		return nil, err
+	}
+
+	if err = pprofrt.StartCPUProfile(cpuFile); err != nil {
+		cpuFile.Close()
+		return nil, fmt.Errorf("could not start CPU profile: %w", err)
+	}
+
+	memFile, err := createProfile(dir, "mem.prof")
+	if err != nil {
+		pprofrt.StopCPUProfile()
+		cpuFile.Close()
+		return nil, err
+	}
+
+	runtime.SetBlockProfileRate(1)
+
+	traceFile, err := createProfile(dir, "trace.out")
+	if err != nil {
+		runtime.SetBlockProfileRate(0)
+		memFile.Close()
+		pprofrt.StopCPUProfile()
+		cpuFile.Close()
+		return nil, err
+	}
+
+	if err := trace.Start(traceFile); err != nil {
+		traceFile.Close()
+		runtime.SetBlockProfileRate(0)
+		memFile.Close()
+		pprofrt.StopCPUProfile()
+		cpuFile.Close()
+		return nil, fmt.Errorf("could not start trace: %w", err)
+	}
+
+	return func() error {
+		var errs []error
+
+		pprofrt.StopCPUProfile()
+		cpuFile.Close()
+
+		runtime.GC()
+		if err := pprofrt.WriteHeapProfile(memFile); err != nil {
+			errs = append(errs, fmt.Errorf("could not write memory profile: %w", err))
+		}
+		memFile.Close()
+
+		blockFile, err := createProfile(dir, "block.prof")
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			if err := pprofrt.Lookup("block").WriteTo(blockFile, 0); err != nil {
+				errs = append(errs, fmt.Errorf("could not write block profile: %w", err))
+			}
+			blockFile.Close()
+		}
+
+		runtime.SetBlockProfileRate(0)
+
+		trace.Stop()
+		traceFile.Close()
+
+		return errors.Join(errs...)
+	}, nil
+}
+
+// Mount registers the standard net/http/pprof handlers, along with the
+// block-rate and mutex-fraction control endpoints, on mux. main.go mounts
+// it on the --profiling-port admin listener whether or not EnableHTTP is
+// set; the main HTTP server does not mount it. ListenAndServe uses it too.
+func Mount(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	for _, name := range []string{"heap", "goroutine", "block", "mutex", "allocs", "threadcreate"} {
+		mux.Handle("/debug/pprof/"+name, pprof.Handler(name))
+	}
+
+	mux.HandleFunc("/debug/pprof/block-rate", handleBlockRate)
+	mux.HandleFunc("/debug/pprof/mutex-fraction", handleMutexFraction)
+}
+
+// ListenAndServe mounts the profiling handlers on a fresh mux and serves
+// them on addr until ctx is done.
+func ListenAndServe(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	Mount(mux)
+
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	errCh := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+			return
+		}
+		errCh <- nil
+	}()
+
+	select {
+	case <-ctx.Done():
+		return srv.Close()
+	case err := <-errCh:
+		return err
+	}
+}
+
+func handleBlockRate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rate, err := strconv.Atoi(r.URL.Query().Get("rate"))
+	if err != nil {
+		http.Error(w, "invalid rate: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// rate<=0 disables sampling, rate=1 samples every blocking event, and any
+	// other N samples one event per N ns of blocking, per runtime.SetBlockProfileRate.
+	runtime.SetBlockProfileRate(rate)
+	fmt.Fprintf(w, "block profile rate set to %d\n", rate)
+}
+
+func handleMutexFraction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	n, err := strconv.Atoi(r.URL.Query().Get("n"))
+	if err != nil {
+		http.Error(w, "invalid n: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	runtime.SetMutexProfileFraction(n)
+	fmt.Fprintf(w, "mutex profile fraction set to %d\n", n)
+}
+
+func createProfile(dir, name string) (*os.File, error) {
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("could not create %s: %w", name, err)
+	}
+	return f, nil
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import "time"
+
+// DropPolicy decides what a full watch channel does with a new event:
+// drop the new event, drop the oldest queued one to make room, or block
+// the producer for a bounded deadline before giving up.
+type DropPolicy int
+
+const (
+	// DropNewest discards the event that didn't fit, keeping everything
+	// already queued.
+	DropNewest DropPolicy = iota
+	// DropOldest discards the longest-queued event to make room for the
+	// new one, favoring freshness over completeness.
+	DropOldest
+	// BlockWithDeadline waits up to a deadline for room, then drops the
+	// new event if none opened up.
+	BlockWithDeadline
+)
+
+// Send delivers v on ch without letting a slow subscriber grow ch's
+// backing buffer unbounded: WatchChanBufSize already bounds the channel
+// itself, this decides what happens once that bound is hit. It reports
+// whether v was delivered.
+func Send[T any](ch chan T, v T, policy DropPolicy, deadline time.Duration) bool {
+	select {
+	case ch <- v:
+		return true
+	default:
+	}
+
+	switch policy {
+	case DropOldest:
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- v:
+			return true
+		default:
+			return false
+		}
+	case BlockWithDeadline:
+		timer := time.NewTimer(deadline)
+		defer timer.Stop()
+		select {
+		case ch <- v:
+			return true
+		case <-timer.C:
+			return false
+		}
+	default: // DropNewest
+		return false
+	}
+}
@@ -0,0 +1,17 @@
+package ratelimit
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// WriteHTTP writes a 429 response for e, with a Retry-After header in
+// seconds as HTTP requires, mirroring RESPMessage for the RESP server.
+func (e *ErrRateLimited) WriteHTTP(w http.ResponseWriter) {
+	retryAfterSec := e.RetryAfter.Seconds()
+	if retryAfterSec < 1 {
+		retryAfterSec = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(retryAfterSec)))
+	http.Error(w, e.Error(), http.StatusTooManyRequests)
+}
@@ -0,0 +1,216 @@
+// Package ratelimit caps how fast DiceDB accepts work: a token bucket per
+// connection, a token bucket per command class (reads, writes, deletes,
+// admin), and a cap on the number of live connections. Allow is meant to
+// be called from the command-dispatch path of each abstractserver
+// implementation (RESP, HTTP, WebSocket), ahead of shard dispatch, and
+// AcquireConnection from their accept loop -- but that wiring lives in
+// internal/server, which this package doesn't reach into, so nothing
+// calls either yet. main.go does not register --limit-* flags until an
+// abstractserver implementation actually adopts this package, so as not
+// to ship a flag that silently does nothing.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CommandClass buckets commands for the purpose of rate limiting, since an
+// operator usually wants a different budget for reads than for deletes.
+type CommandClass int
+
+const (
+	ClassRead CommandClass = iota
+	ClassWrite
+	ClassDelete
+	ClassAdmin
+)
+
+// ClassConfig is one token bucket's rate and burst, in requests per second.
+type ClassConfig struct {
+	RPS   float64
+	Burst int
+}
+
+// Config is everything Limiter needs: a bucket per command class, shared
+// across all connections, plus a cap on live connections and an optional
+// per-connection bucket applied on top of the class bucket.
+type Config struct {
+	Classes        map[CommandClass]ClassConfig
+	PerConnection  ClassConfig // zero Burst disables the per-connection bucket
+	MaxConnections int         // <=0 disables the cap
+}
+
+// Limiter enforces Config across every connection DiceDB serves.
+type Limiter struct {
+	cfg Config
+
+	mu           sync.Mutex
+	classBuckets map[CommandClass]*tokenBucket
+	connBuckets  map[string]*tokenBucket
+	activeConns  int
+}
+
+// New builds a Limiter from cfg. Command classes absent from cfg.Classes,
+// and classes present with RPS<=0 or Burst<=0 (0 disables the limit),
+// are left unlimited rather than given a
+// zero-capacity bucket that would deny every command.
+func New(cfg Config) *Limiter {
+	l := &Limiter{
+		cfg:          cfg,
+		classBuckets: make(map[CommandClass]*tokenBucket, len(cfg.Classes)),
+		connBuckets:  make(map[string]*tokenBucket),
+	}
+	for class, cc := range cfg.Classes {
+		if cc.RPS <= 0 || cc.Burst <= 0 {
+			continue
+		}
+		l.classBuckets[class] = newTokenBucket(cc.RPS, cc.Burst)
+	}
+	return l
+}
+
+// ctxKey is an unexported type so values this package stores in a
+// context.Context can't collide with keys from other packages.
+type ctxKey struct{}
+
+// ContextWithLimiter returns a copy of ctx carrying l, so a server
+// implementation that doesn't take a Limiter constructor argument can
+// still reach it via FromContext.
+func ContextWithLimiter(ctx context.Context, l *Limiter) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the Limiter stored in ctx by ContextWithLimiter, if
+// any.
+func FromContext(ctx context.Context) (*Limiter, bool) {
+	l, ok := ctx.Value(ctxKey{}).(*Limiter)
+	return l, ok
+}
+
+// Allow checks both the connection's own bucket (if PerConnection is
+// configured) and the shared bucket for class, consuming one token from
+// each on success. It returns *ErrRateLimited when either is exhausted.
+func (l *Limiter) Allow(connID string, class CommandClass) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.cfg.PerConnection.Burst > 0 {
+		cb, ok := l.connBuckets[connID]
+		if !ok {
+			cb = newTokenBucket(l.cfg.PerConnection.RPS, l.cfg.PerConnection.Burst)
+			l.connBuckets[connID] = cb
+		}
+		if wait, ok := cb.take(); !ok {
+			return &ErrRateLimited{RetryAfter: wait}
+		}
+	}
+
+	if b, ok := l.classBuckets[class]; ok {
+		if wait, ok := b.take(); !ok {
+			return &ErrRateLimited{RetryAfter: wait}
+		}
+	}
+
+	return nil
+}
+
+// Forget drops a closed connection's bucket so it doesn't leak memory.
+func (l *Limiter) Forget(connID string) {
+	l.mu.Lock()
+	delete(l.connBuckets, connID)
+	l.mu.Unlock()
+}
+
+// AcquireConnection reserves one connection slot against MaxConnections.
+// The caller must call the returned release func when the connection
+// closes. ok is false, with a nil release, when the cap is already hit.
+func (l *Limiter) AcquireConnection() (release func(), ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.cfg.MaxConnections > 0 && l.activeConns >= l.cfg.MaxConnections {
+		return nil, false
+	}
+
+	l.activeConns++
+	released := false
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if released {
+			return
+		}
+		released = true
+		l.activeConns--
+	}, true
+}
+
+// tokenBucket is a classic token bucket: capacity tokens refilling at
+// rps per second, lazily topped up on every take() call.
+type tokenBucket struct {
+	rps      float64
+	capacity float64
+
+	mu       sync.Mutex
+	tokens   float64
+	lastFill time.Time
+}
+
+func newTokenBucket(rps float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rps:      rps,
+		capacity: float64(burst),
+		tokens:   float64(burst),
+		lastFill: time.Now(),
+	}
+}
+
+// take consumes one token if available. When it isn't, it returns the
+// wait duration until the next token would be available, so the caller
+// can report a Retry-After.
+func (b *tokenBucket) take() (wait time.Duration, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(b.lastFill).Seconds()
+	b.tokens = minFloat64(b.capacity, b.tokens+elapsed*b.rps)
+	b.lastFill = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+
+	if b.rps <= 0 {
+		return time.Second, false
+	}
+	return time.Duration((1 - b.tokens) / b.rps * float64(time.Second)), false
+}
+
+func minFloat64(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// ErrRateLimited is returned by Limiter.Allow when a bucket is exhausted.
+// It serializes as `-ERR rate limited retry after Nms` over RESP and as a
+// 429 with a Retry-After header over HTTP -- see RESPMessage and WriteHTTP.
+type ErrRateLimited struct {
+	RetryAfter time.Duration
+}
+
+func (e *ErrRateLimited) Error() string {
+	return fmt.Sprintf("rate limited, retry after %dms", e.RetryAfter.Milliseconds())
+}
+
+// RESPMessage is the exact RESP error line the RESP server should write
+// back to the client for this error.
+func (e *ErrRateLimited) RESPMessage() string {
+	return fmt.Sprintf("-ERR rate limited retry after %dms\r\n", e.RetryAfter.Milliseconds())
+}
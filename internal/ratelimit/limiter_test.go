@@ -0,0 +1,153 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketTakeWithinBurst(t *testing.T) {
+	b := newTokenBucket(10, 3)
+
+	for i := 0; i < 3; i++ {
+		if _, ok := b.take(); !ok {
+			t.Fatalf("take() #%d = false, want true within burst", i)
+		}
+	}
+
+	if _, ok := b.take(); ok {
+		t.Fatal("take() after exhausting burst = true, want false")
+	}
+}
+
+func TestTokenBucketRefillsOverTime(t *testing.T) {
+	b := newTokenBucket(1000, 1)
+
+	if _, ok := b.take(); !ok {
+		t.Fatal("first take() = false, want true")
+	}
+	if _, ok := b.take(); ok {
+		t.Fatal("take() immediately after exhausting burst = true, want false")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := b.take(); !ok {
+		t.Fatal("take() after refill window = false, want true")
+	}
+}
+
+func TestTokenBucketReportsRetryAfter(t *testing.T) {
+	b := newTokenBucket(10, 1)
+	if _, ok := b.take(); !ok {
+		t.Fatal("first take() = false, want true")
+	}
+
+	wait, ok := b.take()
+	if ok {
+		t.Fatal("take() after exhausting burst = true, want false")
+	}
+	if wait <= 0 {
+		t.Fatalf("wait = %v, want a positive retry-after", wait)
+	}
+}
+
+func TestLimiterAllowUnlimitedWhenClassAbsent(t *testing.T) {
+	l := New(Config{})
+	for i := 0; i < 100; i++ {
+		if err := l.Allow("conn-1", ClassRead); err != nil {
+			t.Fatalf("Allow() #%d = %v, want nil for an unconfigured class", i, err)
+		}
+	}
+}
+
+func TestLimiterAllowEnforcesClassBurst(t *testing.T) {
+	l := New(Config{
+		Classes: map[CommandClass]ClassConfig{
+			ClassWrite: {RPS: 10, Burst: 2},
+		},
+	})
+
+	if err := l.Allow("conn-1", ClassWrite); err != nil {
+		t.Fatalf("Allow() #1 = %v, want nil", err)
+	}
+	if err := l.Allow("conn-1", ClassWrite); err != nil {
+		t.Fatalf("Allow() #2 = %v, want nil", err)
+	}
+	if err := l.Allow("conn-1", ClassWrite); err == nil {
+		t.Fatal("Allow() #3 = nil, want ErrRateLimited once the burst is exhausted")
+	}
+}
+
+func TestLimiterAllowEnforcesPerConnectionBucketSeparately(t *testing.T) {
+	l := New(Config{PerConnection: ClassConfig{RPS: 10, Burst: 1}})
+
+	if err := l.Allow("conn-1", ClassRead); err != nil {
+		t.Fatalf("Allow(conn-1) = %v, want nil", err)
+	}
+	if err := l.Allow("conn-1", ClassRead); err == nil {
+		t.Fatal("second Allow(conn-1) = nil, want ErrRateLimited")
+	}
+	if err := l.Allow("conn-2", ClassRead); err != nil {
+		t.Fatalf("Allow(conn-2) = %v, want nil, each connection has its own bucket", err)
+	}
+}
+
+func TestLimiterForgetDropsConnectionBucket(t *testing.T) {
+	l := New(Config{PerConnection: ClassConfig{RPS: 10, Burst: 1}})
+
+	if err := l.Allow("conn-1", ClassRead); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Allow("conn-1", ClassRead); err == nil {
+		t.Fatal("Allow(conn-1) = nil, want ErrRateLimited")
+	}
+
+	l.Forget("conn-1")
+
+	if err := l.Allow("conn-1", ClassRead); err != nil {
+		t.Fatalf("Allow(conn-1) after Forget = %v, want nil, a fresh bucket", err)
+	}
+}
+
+func TestLimiterAcquireConnectionEnforcesCap(t *testing.T) {
+	l := New(Config{MaxConnections: 1})
+
+	release1, ok := l.AcquireConnection()
+	if !ok {
+		t.Fatal("first AcquireConnection() = false, want true")
+	}
+
+	if _, ok := l.AcquireConnection(); ok {
+		t.Fatal("second AcquireConnection() = true, want false once the cap is hit")
+	}
+
+	release1()
+
+	if _, ok := l.AcquireConnection(); !ok {
+		t.Fatal("AcquireConnection() after release = false, want true")
+	}
+}
+
+func TestLimiterAcquireConnectionUnlimitedWhenCapZero(t *testing.T) {
+	l := New(Config{})
+	for i := 0; i < 10; i++ {
+		if _, ok := l.AcquireConnection(); !ok {
+			t.Fatalf("AcquireConnection() #%d = false, want true with MaxConnections disabled", i)
+		}
+	}
+}
+
+func TestContextWithLimiterRoundTrips(t *testing.T) {
+	l := New(Config{})
+	ctx := ContextWithLimiter(context.Background(), l)
+
+	got, ok := FromContext(ctx)
+	if !ok || got != l {
+		t.Fatalf("FromContext() = (%v, %v), want (%v, true)", got, ok, l)
+	}
+
+	if _, ok := FromContext(context.Background()); ok {
+		t.Fatal("FromContext() on a bare context = true, want false")
+	}
+}
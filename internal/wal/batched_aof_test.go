@@ -0,0 +1,149 @@
+package wal
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestBatchedAOFWAL(t *testing.T) *BatchedAOFWAL {
+	t.Helper()
+	dir := t.TempDir()
+	w, err := NewBatchedAOFWAL(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Init(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+	return w
+}
+
+func TestBatchedAOFWALLogCommandPersists(t *testing.T) {
+	w := newTestBatchedAOFWAL(t)
+
+	if err := w.LogCommand([]byte("SET a 1\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.LogCommand([]byte("SET b 2\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	segment := w.file.Name()
+	data, err := os.ReadFile(segment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "SET a 1\nSET b 2\n"; got != want {
+		t.Fatalf("segment content = %q, want %q", got, want)
+	}
+}
+
+func TestBatchedAOFWALFlushesOnMaxEntries(t *testing.T) {
+	w := newTestBatchedAOFWAL(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < batchMaxEntries; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := w.LogCommand([]byte("x")); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	w.mu.Lock()
+	pending := len(w.pending)
+	w.mu.Unlock()
+	if pending != 0 {
+		t.Fatalf("pending = %d after %d entries, want the batch flushed", pending, batchMaxEntries)
+	}
+}
+
+func TestBatchedAOFWALFlushesOnTimer(t *testing.T) {
+	w := newTestBatchedAOFWAL(t)
+
+	done := make(chan error, 1)
+	go func() { done <- w.LogCommand([]byte("lone write")) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("LogCommand did not return within 50ms of the batch timer firing")
+	}
+}
+
+func TestBatchedAOFWALReportsWriteErrorToEveryWaiter(t *testing.T) {
+	w := newTestBatchedAOFWAL(t)
+	if err := w.file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = w.LogCommand([]byte("x"))
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err == nil {
+			t.Errorf("waiter %d: got nil error, want the shared write failure", i)
+		}
+	}
+}
+
+func TestBatchedAOFWALCloseFlushesPending(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewBatchedAOFWAL(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Init(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- w.LogCommand([]byte("flushed by close")) }()
+
+	// Wait for the write to enter the pending batch, then stop the batch
+	// timer so that Close, not the timer, is what flushes it.
+	for {
+		w.mu.Lock()
+		if len(w.pending) > 0 {
+			w.timer.Stop()
+			w.timer = nil
+			w.mu.Unlock()
+			break
+		}
+		w.mu.Unlock()
+		time.Sleep(10 * time.Microsecond)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, filepath.Base(w.file.Name())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "flushed by close" {
+		t.Fatalf("segment content = %q, want the pending write to have been flushed", data)
+	}
+}
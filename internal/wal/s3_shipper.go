@@ -0,0 +1,219 @@
+package wal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dicedb/dice/config"
+)
+
+// s3SegmentMaxAge is how long a segment stays open for writes before it's
+// closed and handed off to the uploader, bounding how much data a crash
+// between uploads could lose.
+const s3SegmentMaxAge = 5 * time.Minute
+
+// S3Uploader uploads a single closed WAL segment. It's an interface, not a
+// concrete AWS SDK client, so engines can be tested against a fake and so
+// any S3-compatible object store (MinIO, R2, GCS's S3 shim, ...) can be
+// plugged in without this package depending on a specific SDK.
+type S3Uploader interface {
+	Upload(ctx context.Context, bucket, key string, body io.Reader) error
+}
+
+// S3ShipperWAL spools WAL segments to logDir like the plain AOF engine,
+// then ships each closed segment to an S3-compatible bucket in the
+// background for disaster recovery. Writes only ever touch the local
+// disk; the upload path can fall arbitrarily behind without blocking
+// LogCommand, at the cost of a larger recovery-point gap if the process
+// crashes before a segment uploads.
+type S3ShipperWAL struct {
+	logDir string
+	bucket string
+	prefix string
+	upload S3Uploader
+
+	mu          sync.Mutex
+	file        *os.File
+	segmentPath string
+	segmentOpen time.Time
+
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewS3ShipperWAL creates an engine that spools segments under logDir and
+// ships closed ones to bucket/prefix via upload.
+func NewS3ShipperWAL(logDir, bucket, prefix string, upload S3Uploader) (*S3ShipperWAL, error) {
+	return &S3ShipperWAL{
+		logDir:  logDir,
+		bucket:  bucket,
+		prefix:  prefix,
+		upload:  upload,
+		closeCh: make(chan struct{}),
+	}, nil
+}
+
+// Init opens the first local segment and starts the background roller
+// that closes and ships a segment every s3SegmentMaxAge.
+func (w *S3ShipperWAL) Init(startTime time.Time) error {
+	if err := os.MkdirAll(w.logDir, 0o755); err != nil {
+		return fmt.Errorf("could not create WAL dir %q: %w", w.logDir, err)
+	}
+
+	if err := w.rollSegment(startTime); err != nil {
+		return err
+	}
+
+	w.wg.Add(1)
+	go w.rollLoop()
+
+	return nil
+}
+
+func (w *S3ShipperWAL) rollLoop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(s3SegmentMaxAge)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.closeCh:
+			return
+		case <-ticker.C:
+			w.mu.Lock()
+			closed := w.segmentPath
+			err := w.rollSegment(time.Now())
+			w.mu.Unlock()
+			if err != nil {
+				continue
+			}
+			w.shipAsync(closed)
+		}
+	}
+}
+
+// rollSegment closes the current segment (if any) and opens a fresh one
+// named by t; shipping the closed segment is left to the caller. Callers
+// must hold w.mu.
+func (w *S3ShipperWAL) rollSegment(t time.Time) error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return fmt.Errorf("could not close WAL segment %q: %w", w.segmentPath, err)
+		}
+	}
+
+	name := filepath.Join(w.logDir, fmt.Sprintf("s3-wal-%d.log", t.UnixNano()))
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("could not open WAL segment %q: %w", name, err)
+	}
+
+	w.file = f
+	w.segmentPath = name
+	w.segmentOpen = t
+	return nil
+}
+
+// shipAsync uploads a closed segment in the background; open and upload
+// failures are logged -- this engine does not retry, since a segment is
+// never deleted locally until ReplayWAL or an operator cleans it up.
+func (w *S3ShipperWAL) shipAsync(segmentPath string) {
+	if segmentPath == "" {
+		return
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		f, err := os.Open(segmentPath)
+		if err != nil {
+			slog.Error("could not open WAL segment for upload", slog.String("segment", segmentPath), slog.Any("error", err))
+			return
+		}
+		defer f.Close()
+
+		key := w.prefix + "/" + filepath.Base(segmentPath)
+		if err := w.upload.Upload(context.Background(), w.bucket, key, f); err != nil {
+			slog.Error("could not upload WAL segment", slog.String("segment", segmentPath), slog.Any("error", err))
+		}
+	}()
+}
+
+// LogCommand appends data to the currently open local segment.
+func (w *S3ShipperWAL) LogCommand(data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := w.file.Write(data); err != nil {
+		return fmt.Errorf("could not write WAL entry: %w", err)
+	}
+	return w.file.Sync()
+}
+
+// Close stops the roller, ships the final segment, and closes the local file.
+func (w *S3ShipperWAL) Close() error {
+	close(w.closeCh)
+
+	w.mu.Lock()
+	closed := w.segmentPath
+	err := w.file.Close()
+	w.mu.Unlock()
+
+	w.shipAsync(closed)
+	w.wg.Wait()
+
+	return err
+}
+
+// httpUploader uploads a segment with a plain HTTP PUT, which every
+// S3-compatible store (AWS S3, MinIO, R2, ...) accepts against a
+// presigned or otherwise pre-authorized endpoint. It exists so this
+// engine has no hard dependency on a specific vendor SDK.
+type httpUploader struct {
+	endpoint string
+}
+
+func (u *httpUploader) Upload(ctx context.Context, bucket, key string, body io.Reader) error {
+	url := strings.TrimRight(u.endpoint, "/") + "/" + bucket + "/" + key
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, body)
+	if err != nil {
+		return fmt.Errorf("could not build upload request for %q: %w", url, err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not upload %q: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("upload of %q failed with status %s", url, resp.Status)
+	}
+	return nil
+}
+
+func init() {
+	// config.WALS3Endpoint/Bucket/Prefix are registered in main.go's
+	// settings registry like every other setting (--wal-s3-endpoint
+	// flag, DICE_WAL_S3_ENDPOINT env, config-file key), the same way
+	// NewSQLiteWAL and NewAOFWAL read their settings off the config
+	// package today. This factory runs lazily from wal.New, by which
+	// point main's init() has already populated them.
+	Register("s3", func(logDir string) (AbstractWAL, error) {
+		if config.WALS3Endpoint == "" || config.WALS3Bucket == "" {
+			return nil, fmt.Errorf("wal-engine s3 requires --wal-s3-endpoint and --wal-s3-bucket")
+		}
+
+		return NewS3ShipperWAL(logDir, config.WALS3Bucket, config.WALS3Prefix, &httpUploader{endpoint: config.WALS3Endpoint})
+	})
+}
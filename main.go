@@ -10,17 +10,21 @@ import (
 	"os"
 	"os/signal"
 	"runtime"
-	"runtime/pprof"
-	"runtime/trace"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/dicedb/dice/internal/configschema"
+	"github.com/dicedb/dice/internal/lifecycle"
 	"github.com/dicedb/dice/internal/logger"
+	"github.com/dicedb/dice/internal/observability/otel"
+	"github.com/dicedb/dice/internal/observability/profiling"
 	"github.com/dicedb/dice/internal/server/abstractserver"
 	"github.com/dicedb/dice/internal/wal"
 	"github.com/dicedb/dice/internal/watchmanager"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/dicedb/dice/config"
 	diceerrors "github.com/dicedb/dice/internal/errors"
@@ -35,43 +39,103 @@ import (
 type configEntry struct {
 	Key   string
 	Value interface{}
+	// Source reports which layer the value came from: flag, env, file, or
+	// the built-in default. Empty for entries outside the config registry.
+	Source string
 }
 
 var configTable = []configEntry{}
 
+// profilingPort is the dedicated admin listener for the net/http/pprof
+// handlers, the /healthz, /livez, /readyz probes, and /metrics. It runs
+// regardless of EnableHTTP, since the main HTTP server doesn't mount these.
+var profilingPort int
+
+// shutdownDrainTimeoutSec bounds how long the first SIGTERM/SIGINT waits
+// for the server goroutines to return before the shutdown forces a context
+// cancellation anyway. In-flight commands and WAL fsyncs are not tracked
+// individually.
+var shutdownDrainTimeoutSec int
+
+// otelEndpoint is the OTLP collector address for traces/metrics. Empty
+// disables the OTLP exporters; the /metrics Prometheus endpoint stays up
+// regardless so scrape-based deployments work without a collector.
+var otelEndpoint string
+
+// settings is the single registry describing every DiceDB startup setting.
+// It drives flag registration, the -c config file, DICE_* env overrides,
+// and the printed config table, with precedence flag > env > file > default.
+var settings = configschema.New()
+
 func init() {
-	flag.StringVar(&config.Host, "host", "0.0.0.0", "host for the DiceDB server")
+	settings.StringVar(&config.Host, "host", "0.0.0.0", "host for the DiceDB server")
+
+	settings.IntVar(&config.Port, "port", 7379, "port for the DiceDB server")
 
-	flag.IntVar(&config.Port, "port", 7379, "port for the DiceDB server")
+	settings.IntVar(&config.HTTPPort, "http-port", 7380, "port for accepting requets over HTTP")
+	settings.BoolVar(&config.EnableHTTP, "enable-http", false, "enable DiceDB to listen, accept, and process HTTP")
 
-	flag.IntVar(&config.HTTPPort, "http-port", 7380, "port for accepting requets over HTTP")
-	flag.BoolVar(&config.EnableHTTP, "enable-http", false, "enable DiceDB to listen, accept, and process HTTP")
+	settings.IntVar(&config.WebsocketPort, "websocket-port", 7381, "port for accepting requets over WebSocket")
+	settings.BoolVar(&config.EnableWebsocket, "enable-websocket", false, "enable DiceDB to listen, accept, and process WebSocket")
 
-	flag.IntVar(&config.WebsocketPort, "websocket-port", 7381, "port for accepting requets over WebSocket")
-	flag.BoolVar(&config.EnableWebsocket, "enable-websocket", false, "enable DiceDB to listen, accept, and process WebSocket")
+	settings.BoolVar(&config.EnableMultiThreading, "enable-multithreading", false, "enable multithreading execution and leverage multiple CPU cores")
+	settings.IntVar(&config.NumShards, "num-shards", -1, "number shards to create. defaults to number of cores")
 
-	flag.BoolVar(&config.EnableMultiThreading, "enable-multithreading", false, "enable multithreading execution and leverage multiple CPU cores")
-	flag.IntVar(&config.NumShards, "num-shards", -1, "number shards to create. defaults to number of cores")
+	settings.BoolVar(&config.EnableWatch, "enable-watch", false, "enable support for .WATCH commands and real-time reactivity")
+	settings.BoolVar(&config.EnableProfiling, "enable-profiling", false, "enable profiling and capture critical metrics and traces in .prof files")
+	settings.IntVar(&profilingPort, "profiling-port", 7382, "port for the live /debug/pprof, /healthz, /livez, /readyz, and /metrics admin HTTP endpoint")
+	settings.IntVar(&shutdownDrainTimeoutSec, "shutdown-drain-timeout", 30, "seconds to wait for the servers to stop "+
+		"on the first SIGTERM/SIGINT before forcing shutdown; a second signal forces an immediate abort")
 
-	flag.BoolVar(&config.EnableWatch, "enable-watch", false, "enable support for .WATCH commands and real-time reactivity")
-	flag.BoolVar(&config.EnableProfiling, "enable-profiling", false, "enable profiling and capture critical metrics and traces in .prof files")
+	settings.StringVar(&otelEndpoint, "otel-endpoint", "", "OTLP gRPC collector endpoint for traces and metrics, empty disables OTLP export")
 
-	flag.StringVar(&config.DiceConfig.Logging.LogLevel, "log-level", "info", "log level, values: info, debug")
-	flag.StringVar(&config.LogDir, "log-dir", "/tmp/dicedb", "log directory path")
+	settings.StringVar(&config.DiceConfig.Logging.LogLevel, "log-level", "info", "log level, values: info, debug")
+	settings.StringVar(&config.LogDir, "log-dir", "/tmp/dicedb", "log directory path")
 
-	flag.BoolVar(&config.EnableWAL, "enable-wal", false, "enable write-ahead logging")
-	flag.BoolVar(&config.RestoreFromWAL, "restore-wal", false, "restore the database from the WAL files")
-	flag.StringVar(&config.WALEngine, "wal-engine", "null", "wal engine to use, values: sqlite, aof")
+	settings.BoolVar(&config.EnableWAL, "enable-wal", false, "enable write-ahead logging")
+	settings.BoolVar(&config.RestoreFromWAL, "restore-wal", false, "restore the database from the WAL files")
+	settings.StringVar(&config.WALEngine, "wal-engine", "null", "wal engine to use, values: sqlite, aof, aof-batched, s3, null")
+	settings.StringVar(&config.WALS3Endpoint, "wal-s3-endpoint", "", "S3-compatible endpoint for the s3 wal-engine, required when --wal-engine=s3")
+	settings.StringVar(&config.WALS3Bucket, "wal-s3-bucket", "", "bucket for the s3 wal-engine, required when --wal-engine=s3")
+	settings.StringVar(&config.WALS3Prefix, "wal-s3-prefix", "", "key prefix for the s3 wal-engine's uploaded segments")
 
-	flag.StringVar(&config.RequirePass, "requirepass", config.RequirePass, "enable authentication for the default user")
-	flag.StringVar(&config.CustomConfigFilePath, "o", config.CustomConfigFilePath, "dir path to create the config file")
-	flag.StringVar(&config.FileLocation, "c", config.FileLocation, "file path of the config file")
-	flag.BoolVar(&config.InitConfigCmd, "init-config", false, "initialize a new config file")
-	flag.IntVar(&config.KeysLimit, "keys-limit", config.KeysLimit, "keys limit for the DiceDB server. "+
+	settings.StringVar(&config.RequirePass, "requirepass", config.RequirePass, "enable authentication for the default user")
+	settings.StringVar(&config.CustomConfigFilePath, "o", config.CustomConfigFilePath, "dir path to create the config file")
+	settings.BoolVar(&config.InitConfigCmd, "init-config", false, "initialize a new config file")
+	settings.IntVar(&config.KeysLimit, "keys-limit", config.KeysLimit, "keys limit for the DiceDB server. "+
 		"This flag controls the number of keys each shard holds at startup. You can multiply this number with the "+
 		"total number of shard threads to estimate how much memory will be required at system start up.")
 
+	// "c" is resolved ahead of the rest of the registry: its own value
+	// (flag > DICE_C env > default) decides which config file the other
+	// entries' file layer is loaded from. Its Source is set explicitly
+	// from that resolution rather than left for LoadEnv/flag.Visit to
+	// re-derive, so the printed table reports where it actually came
+	// from even though it's already resolved by the time the registry
+	// exists.
+	var cSource configschema.Source
+	config.FileLocation, cSource = configschema.ResolveConfigPath(os.Args[1:], "c", config.FileLocation)
+	settings.StringVar(&config.FileLocation, "c", config.FileLocation, "file path of the config file")
+	settings.SetSource("c", cSource)
+
+	if err := settings.LoadFile(config.FileLocation); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := settings.LoadEnv(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// Respect the standard OTEL_EXPORTER_OTLP_ENDPOINT alongside our own
+	// DICE_OTEL_ENDPOINT, so existing OTel tooling configures DiceDB too.
+	if otelEndpoint == "" {
+		otelEndpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+
+	settings.RegisterFlags(flag.CommandLine)
 	flag.Parse()
+	flag.Visit(func(f *flag.Flag) { settings.MarkFlagSet(f.Name) })
 
 	config.SetupConfig()
 
@@ -111,12 +175,12 @@ func configuration() {
 	// Conditionally add the number of shards to be used for DiceDB to the configuration table
 	if config.EnableMultiThreading {
 		if config.NumShards > 0 {
-			configTable = append(configTable, configEntry{"Shards", config.NumShards})
+			addEntry("Shards", config.NumShards)
 		} else {
-			configTable = append(configTable, configEntry{"Shards", runtime.NumCPU()})
+			addEntry("Shards", runtime.NumCPU())
 		}
 	} else {
-		configTable = append(configTable, configEntry{"Shards", 1})
+		addEntry("Shards", 1)
 	}
 
 	// Add whether the watch feature is enabled to the configuration table
@@ -130,38 +194,64 @@ func configuration() {
 }
 
 func addEntry(k string, v interface{}) {
-	configTable = append(configTable, configEntry{k, v})
+	configTable = append(configTable, configEntry{Key: k, Value: v})
 }
 
-// printConfigTable prints key-value pairs in a vertical table format.
+// printConfigTable prints key-value pairs in a vertical table format, then
+// the full settings registry with the layer (flag/env/file/default) each
+// value was resolved from.
 func printConfigTable() {
 	configuration()
 
-	// Find the longest key to align the values properly
+	printTable("Configuration", configTable)
+
+	registryTable := make([]configEntry, 0, len(settings.Entries()))
+	for _, e := range settings.Entries() {
+		registryTable = append(registryTable, configEntry{Key: e.Name, Value: e.Value(), Source: string(e.Source)})
+	}
+	printTable("Setting (source)", registryTable)
+}
+
+// printTable renders entries as a vertical table. When any entry carries a
+// Source, a third column is added showing which layer resolved it.
+func printTable(header string, entries []configEntry) {
+	showSource := false
 	maxKeyLength := 0
 	maxValueLength := 20 // Default value length for alignment
-	for _, entry := range configTable {
+	maxSourceLength := len("Source")
+	for _, entry := range entries {
 		if len(entry.Key) > maxKeyLength {
 			maxKeyLength = len(entry.Key)
 		}
 		if len(fmt.Sprintf("%v", entry.Value)) > maxValueLength {
 			maxValueLength = len(fmt.Sprintf("%v", entry.Value))
 		}
+		if entry.Source != "" {
+			showSource = true
+		}
 	}
 
-	// Create the table header and separator line
 	fmt.Println()
 	totalWidth := maxKeyLength + maxValueLength + 7 // 7 is for spacing and pipes
+	if showSource {
+		totalWidth += maxSourceLength + 3
+	}
 	fmt.Println(strings.Repeat("-", totalWidth))
-	fmt.Printf("| %-*s | %-*s |\n", maxKeyLength, "Configuration", maxValueLength, "Value")
+	if showSource {
+		fmt.Printf("| %-*s | %-*s | %-*s |\n", maxKeyLength, header, maxValueLength, "Value", maxSourceLength, "Source")
+	} else {
+		fmt.Printf("| %-*s | %-*s |\n", maxKeyLength, header, maxValueLength, "Value")
+	}
 	fmt.Println(strings.Repeat("-", totalWidth))
 
-	// Print each configuration key-value pair without row lines
-	for _, entry := range configTable {
-		fmt.Printf("| %-*s | %-20v |\n", maxKeyLength, entry.Key, entry.Value)
+	for _, entry := range entries {
+		if showSource {
+			fmt.Printf("| %-*s | %-20v | %-*s |\n", maxKeyLength, entry.Key, entry.Value, maxSourceLength, entry.Source)
+		} else {
+			fmt.Printf("| %-*s | %-20v |\n", maxKeyLength, entry.Key, entry.Value)
+		}
 	}
 
-	// Final bottom line
 	fmt.Println(strings.Repeat("-", totalWidth))
 	fmt.Println()
 }
@@ -189,27 +279,13 @@ func main() {
 	wl, _ = wal.NewNullWAL()
 	slog.Info("running with", slog.Bool("enable-wal", config.EnableWAL))
 	if config.EnableWAL {
-		if config.WALEngine == "sqlite" {
-			_wl, err := wal.NewSQLiteWAL(config.LogDir)
-			if err != nil {
-				slog.Warn("could not create WAL with", slog.String("wal-engine", config.WALEngine), slog.Any("error", err))
-				sigs <- syscall.SIGKILL
-				return
-			}
-			wl = _wl
-		} else if config.WALEngine == "aof" {
-			_wl, err := wal.NewAOFWAL(config.LogDir)
-			if err != nil {
-				slog.Warn("could not create WAL with", slog.String("wal-engine", config.WALEngine), slog.Any("error", err))
-				sigs <- syscall.SIGKILL
-				return
-			}
-			wl = _wl
-		} else {
-			slog.Error("unsupported WAL engine", slog.String("engine", config.WALEngine))
+		_wl, err := wal.New(config.WALEngine, config.LogDir)
+		if err != nil {
+			slog.Error("could not create WAL", slog.String("wal-engine", config.WALEngine), slog.Any("error", err))
 			sigs <- syscall.SIGKILL
 			return
 		}
+		wl = _wl
 
 		if err := wl.Init(time.Now()); err != nil {
 			slog.Error("could not initialize WAL", slog.Any("error", err))
@@ -230,6 +306,14 @@ func main() {
 		bufSize := config.DiceConfig.Performance.WatchChanBufSize
 		queryWatchChan = make(chan dstore.QueryWatchEvent, bufSize)
 		cmdWatchChan = make(chan dstore.CmdWatchEvent, bufSize)
+		// These channels are sized by WatchChanBufSize but otherwise
+		// plain: the producers that publish onto them live in
+		// internal/store and internal/shard, outside this change, so a
+		// slow subscriber can still grow the producer's wait time
+		// unbounded once the buffer fills. ratelimit.Send(ch, event,
+		// ratelimit.DropOldest, ...) is the backpressure policy those
+		// producers should adopt, but main.go isn't where they send from,
+		// so it can't be wired in here.
 	}
 
 	// Get the number of available CPU cores on the machine using runtime.NumCPU().
@@ -253,6 +337,31 @@ func main() {
 	// improving concurrency performance across multiple goroutines.
 	runtime.GOMAXPROCS(runtime.NumCPU())
 
+	// otelProviders must be ready before the ShardManager exists: ctx is
+	// annotated with it below, ahead of the ShardManager.Run(ctx) and
+	// server goroutines, so any of them can pull the tracer/meter back out
+	// with otel.FromContext(ctx) instead of passing Providers around
+	// explicitly. Full request-lifecycle spans (accept -> parse ->
+	// shard-dispatch -> execute -> WAL-append -> reply) still need that
+	// call added inside internal/shard and internal/store, which this
+	// change doesn't touch.
+	otelProviders, err := otel.Setup(ctx, otel.Config{Endpoint: otelEndpoint, InstanceID: config.DiceConfig.InstanceID})
+	if err != nil {
+		slog.Error("could not set up OpenTelemetry", slog.Any("error", err))
+		sigs <- syscall.SIGKILL
+		return
+	}
+	defer func() {
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelShutdown()
+		if err := otelProviders.Shutdown(shutdownCtx); err != nil {
+			slog.Warn("error shutting down OpenTelemetry providers", slog.Any("error", err))
+		}
+	}()
+
+	gate := lifecycle.NewGate()
+	ctx = otel.ContextWithProviders(ctx, otelProviders)
+
 	// Initialize the ShardManager
 	shardManager := shard.NewShardManager(uint8(numShards), queryWatchChan, cmdWatchChan, serverErrCh)
 
@@ -266,20 +375,55 @@ func main() {
 
 	var serverWg sync.WaitGroup
 
-	if config.EnableMultiThreading {
-		if config.EnableProfiling {
-			stopProfiling, err := startProfiling()
-			if err != nil {
-				slog.Error("Profiling could not be started", slog.Any("error", err))
-				sigs <- syscall.SIGKILL
+	if config.EnableProfiling {
+		stopProfiling, err := profiling.StartFileDump(config.LogDir)
+		if err != nil {
+			slog.Error("Profiling could not be started", slog.Any("error", err))
+			sigs <- syscall.SIGKILL
+		}
+		defer func() {
+			if err := stopProfiling(); err != nil {
+				slog.Warn("error while finalizing profiles", slog.Any("error", err))
 			}
-			defer stopProfiling()
+		}()
+	}
+
+	// The admin HTTP listener on --profiling-port always runs, regardless
+	// of --enable-http: the main HTTP server (internal/server) isn't part
+	// of this change and doesn't mount these itself, so a dedicated
+	// listener is the only place operators can reach net/http/pprof, the
+	// /healthz, /livez, /readyz probes, and /metrics. Its ConnState hook
+	// makes it stop accepting new connections the moment the readiness
+	// gate starts draining, which is the behavior every abstractserver
+	// implementation should adopt for its own listener once this change's
+	// limiter/gate reach internal/server.
+	adminMux := http.NewServeMux()
+	profiling.Mount(adminMux)
+	gate.Mount(adminMux)
+	otelProviders.Mount(adminMux)
+	adminAddr := fmt.Sprintf("%s:%d", config.Host, profilingPort)
+	adminSrv := &http.Server{Addr: adminAddr, Handler: adminMux, ConnState: gate.RejectNewConnections}
+
+	serverWg.Add(1)
+	go func() {
+		defer serverWg.Done()
+		if err := adminSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("admin HTTP listener error", slog.Any("error", err))
 		}
+	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-ctx.Done()
+		_ = adminSrv.Close()
+	}()
+
+	if config.EnableMultiThreading {
 		workerManager := worker.NewWorkerManager(config.DiceConfig.Performance.MaxClients, shardManager)
 		respServer := resp.NewServer(shardManager, workerManager, cmdWatchSubscriptionChan, cmdWatchChan, serverErrCh, wl)
 		serverWg.Add(1)
-		go runServer(ctx, &serverWg, respServer, serverErrCh)
+		go runServer(ctx, &serverWg, respServer, serverErrCh, "resp")
 	} else {
 		asyncServer := server.NewAsyncServer(shardManager, queryWatchChan, wl)
 		if err := asyncServer.FindPortAndBind(); err != nil {
@@ -288,25 +432,59 @@ func main() {
 		}
 
 		serverWg.Add(1)
-		go runServer(ctx, &serverWg, asyncServer, serverErrCh)
+		go runServer(ctx, &serverWg, asyncServer, serverErrCh, "async")
 
 		if config.EnableHTTP {
 			httpServer := server.NewHTTPServer(shardManager, wl)
 			serverWg.Add(1)
-			go runServer(ctx, &serverWg, httpServer, serverErrCh)
+			go runServer(ctx, &serverWg, httpServer, serverErrCh, "http")
 		}
 	}
 
 	if config.EnableWebsocket {
 		websocketServer := server.NewWebSocketServer(shardManager, config.WebsocketPort, wl)
 		serverWg.Add(1)
-		go runServer(ctx, &serverWg, websocketServer, serverErrCh)
+		go runServer(ctx, &serverWg, websocketServer, serverErrCh, "websocket")
 	}
 
+	// Two-phase graceful shutdown: the first signal flips the readiness
+	// gate so /readyz starts failing and load balancers stop routing new
+	// connections here, then waits up to --shutdown-drain-timeout for every
+	// goroutine in serverWg to return before cancelling ctx. serverWg holds
+	// the admin listener, which only returns once ctx is cancelled, so the
+	// first signal normally waits out the whole timeout; in-flight commands
+	// and WAL fsyncs are not tracked individually.
+	// A second signal aborts immediately instead of waiting out the drain.
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		<-sigs
+		slog.Info("draining before shutdown", slog.Int("shutdown-drain-timeout-sec", shutdownDrainTimeoutSec))
+		gate.BeginDraining()
+		// Only the admin listener's ConnState hook actually stops
+		// accepting new connections here today; the RESP/HTTP/WebSocket
+		// servers have no equivalent hook into gate.Draining() yet, since
+		// their accept loops live in internal/server, outside this
+		// change. Until they do, a client can keep opening new
+		// connections on those ports for the rest of the drain window
+		// even though /readyz is already reporting unready.
+		slog.Warn("readiness gate draining, but only the admin listener stops accepting new connections; " +
+			"RESP/HTTP/WebSocket listeners keep accepting until cancellation")
+
+		drained := make(chan struct{})
+		go func() {
+			serverWg.Wait()
+			close(drained)
+		}()
+
+		select {
+		case <-drained:
+			slog.Info("all servers drained")
+		case <-time.After(time.Duration(shutdownDrainTimeoutSec) * time.Second):
+			slog.Warn("drain timeout exceeded, forcing shutdown")
+		case <-sigs:
+			slog.Warn("second shutdown signal received, aborting immediately")
+		}
 		cancel()
 	}()
 
@@ -334,8 +512,23 @@ func main() {
 	wg.Wait()
 }
 
-func runServer(ctx context.Context, wg *sync.WaitGroup, srv abstractserver.AbstractServer, errCh chan<- error) {
+// runServer runs srv until ctx is done, reporting its up/down lifecycle as
+// a span and the dicedb.server.up gauge under label (e.g. "resp", "http").
+// This is a server-lifecycle span, not a per-request one: per-command
+// spans and per-connection gauges need a hook inside srv.Run itself, in
+// internal/server, which isn't part of this change.
+func runServer(ctx context.Context, wg *sync.WaitGroup, srv abstractserver.AbstractServer, errCh chan<- error, label string) {
 	defer wg.Done()
+
+	if p, ok := otel.FromContext(ctx); ok {
+		spanCtx, span := p.Tracer.Start(ctx, "server.run", trace.WithAttributes(attribute.String("server", label)))
+		p.Metrics.ServerStarted(spanCtx, label)
+		defer func() {
+			p.Metrics.ServerStopped(spanCtx, label)
+			span.End()
+		}()
+	}
+
 	if err := srv.Run(ctx); err != nil {
 		switch {
 		case errors.Is(err, context.Canceled):
@@ -352,77 +545,3 @@ func runServer(ctx context.Context, wg *sync.WaitGroup, srv abstractserver.Abstr
 		slog.Debug("bye.")
 	}
 }
-func startProfiling() (func(), error) {
-	// Start CPU profiling
-	cpuFile, err := os.Create("cpu.prof")
-	if err != nil {
-		return nil, fmt.Errorf("could not create cpu.prof: %w", err)
-	}
-
-	if err = pprof.StartCPUProfile(cpuFile); err != nil {
-		cpuFile.Close()
-		return nil, fmt.Errorf("could not start CPU profile: %w", err)
-	}
-
-	// Start memory profiling
-	memFile, err := os.Create("mem.prof")
-	if err != nil {
-		pprof.StopCPUProfile()
-		cpuFile.Close()
-		return nil, fmt.Errorf("could not create mem.prof: %w", err)
-	}
-
-	// Start block profiling
-	runtime.SetBlockProfileRate(1)
-
-	// Start execution trace
-	traceFile, err := os.Create("trace.out")
-	if err != nil {
-		runtime.SetBlockProfileRate(0)
-		memFile.Close()
-		pprof.StopCPUProfile()
-		cpuFile.Close()
-		return nil, fmt.Errorf("could not create trace.out: %w", err)
-	}
-
-	if err := trace.Start(traceFile); err != nil {
-		traceFile.Close()
-		runtime.SetBlockProfileRate(0)
-		memFile.Close()
-		pprof.StopCPUProfile()
-		cpuFile.Close()
-		return nil, fmt.Errorf("could not start trace: %w", err)
-	}
-
-	// Return a cleanup function
-	return func() {
-		// Stop the CPU profiling and close cpuFile
-		pprof.StopCPUProfile()
-		cpuFile.Close()
-
-		// Write heap profile
-		runtime.GC()
-		if err := pprof.WriteHeapProfile(memFile); err != nil {
-			slog.Warn("could not write memory profile", slog.Any("error", err))
-		}
-
-		memFile.Close()
-
-		// Write block profile
-		blockFile, err := os.Create("block.prof")
-		if err != nil {
-			slog.Warn("could not create block profile", slog.Any("error", err))
-		} else {
-			if err := pprof.Lookup("block").WriteTo(blockFile, 0); err != nil {
-				slog.Warn("could not write block profile", slog.Any("error", err))
-			}
-			blockFile.Close()
-		}
-
-		runtime.SetBlockProfileRate(0)
-
-		// Stop trace and close traceFile
-		trace.Stop()
-		traceFile.Close()
-	}, nil
-}